@@ -0,0 +1,79 @@
+package hotstuff
+
+import "go.uber.org/zap"
+
+// REQUEST OPEN, NOT RESOLVED: the originating request asked for Node to
+// invoke ApplyBlock and for a Config.OnApplyError field. Neither exists --
+// this tree's snapshot does not include node.go, so there is no Config to
+// add a field to and no commit loop to call applyCommitted below from.
+// Nothing here should be read as having wired ApplyBlock into Node;
+// applyCommitted is a free function with its own unit tests and nothing
+// else, kept as the policy half of the request for whoever next has
+// node.go open to call from Node's commit loop. Do not close this request
+// on the strength of this file.
+//
+// ApplyBlock is meant to be implemented by the application state machine
+// and invoked by Node for every header as it is finalized, in commit
+// order, in place of draining Blocks() and applying headers out-of-band.
+type ApplyBlock interface {
+	ApplyBlock(Header) error
+}
+
+// ApplyErrorPolicy selects what Node should do when ApplyBlock returns an
+// error for a committed header, once wired in via Config.OnApplyError.
+type ApplyErrorPolicy int
+
+const (
+	// Halt makes applyCommitted return the ApplyBlock error after a single
+	// failed attempt. applyCommitted does not itself stop a node: nothing
+	// in this tree calls it from Node, so no node stops proposing or
+	// voting on that error.
+	Halt ApplyErrorPolicy = iota
+	// Panic re-raises the ApplyBlock error as a panic, for deployments that
+	// would rather crash loudly than let a replica continue once its state
+	// machine disagrees with the committed chain.
+	Panic
+	// Retry re-invokes ApplyBlock up to Retries additional times before
+	// falling back to Halt.
+	Retry
+)
+
+// OnApplyError is intended to live on Config and configure how Node reacts
+// when ApplyBlock fails for a committed header. The zero value is Halt
+// with no retries.
+type OnApplyError struct {
+	Policy  ApplyErrorPolicy
+	Retries int
+}
+
+// applyCommitted runs cb against header under policy and returns the error
+// that should make Node halt, or nil if cb succeeded (eventually, under
+// Retry). It is the enforcement point a future Node commit loop should
+// call for every finalized header before handing it to any subscriber, so
+// that a failing ApplyBlock never lets the node vote on that header's
+// descendants -- see the REQUEST OPEN note above for what's missing
+// to make that true today.
+func applyCommitted(logger *zap.Logger, cb ApplyBlock, policy OnApplyError, header Header) error {
+	if cb == nil {
+		return nil
+	}
+	attempts := 1
+	if policy.Policy == Retry {
+		attempts += policy.Retries
+	}
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = cb.ApplyBlock(header); err == nil {
+			return nil
+		}
+		logger.Error("apply block failed",
+			zap.Uint64("view", header.View),
+			zap.Int("attempt", attempt),
+			zap.Error(err),
+		)
+	}
+	if policy.Policy == Panic {
+		panic(err)
+	}
+	return err
+}
@@ -0,0 +1,76 @@
+package hotstuff
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type mockApplyBlock struct {
+	failures int
+	applied  []uint64
+}
+
+func (m *mockApplyBlock) ApplyBlock(h Header) error {
+	if m.failures > 0 {
+		m.failures--
+		return errors.New("apply failed")
+	}
+	m.applied = append(m.applied, h.View)
+	return nil
+}
+
+func TestApplyCommittedHaltsOnError(t *testing.T) {
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	cb := &mockApplyBlock{failures: 1}
+	err = applyCommitted(logger, cb, OnApplyError{Policy: Halt}, Header{View: 1})
+	require.Error(t, err)
+	require.Empty(t, cb.applied)
+}
+
+func TestApplyCommittedRetriesThenSucceeds(t *testing.T) {
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	cb := &mockApplyBlock{failures: 2}
+	err = applyCommitted(logger, cb, OnApplyError{Policy: Retry, Retries: 2}, Header{View: 1})
+	require.NoError(t, err)
+	require.Equal(t, []uint64{1}, cb.applied)
+}
+
+func TestApplyCommittedRetriesExhaustedHalts(t *testing.T) {
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	cb := &mockApplyBlock{failures: 3}
+	err = applyCommitted(logger, cb, OnApplyError{Policy: Retry, Retries: 2}, Header{View: 1})
+	require.Error(t, err)
+	require.Empty(t, cb.applied)
+}
+
+func TestApplyCommittedPanics(t *testing.T) {
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	cb := &mockApplyBlock{failures: 1}
+	require.Panics(t, func() {
+		_ = applyCommitted(logger, cb, OnApplyError{Policy: Panic}, Header{View: 1})
+	})
+}
+
+// TestNodeHaltsOnApplyError_RequestOpen is a placeholder, not coverage,
+// and its skip is not a pass: this request -- Node invoking ApplyBlock
+// and enforcing Config.OnApplyError -- is not resolved by this tree.
+// Proving "the node actually halts on a failing ApplyBlock" requires
+// Node's own commit loop to call applyCommitted, which lives outside this
+// snapshot's tree (node.go is not present here). applyCommitted's own
+// unit tests above are the closest coverage available until a future
+// change lands node.go and wires this for real; until then, this request
+// should stay open, not be treated as closed.
+func TestNodeHaltsOnApplyError_RequestOpen(t *testing.T) {
+	t.Skip("REQUEST OPEN: requires Node to call applyCommitted from its commit loop; node.go is not part of this snapshot")
+}
@@ -0,0 +1,234 @@
+package hotstuff
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dshulyak/go-hotstuff/netsim"
+	"github.com/stretchr/testify/require"
+)
+
+// ByzantineBehavior lets a test harness corrupt what a node broadcasts,
+// independently of the network schedule applied on top of it in
+// nodeProgress. It is invoked once per destination peer as a byzantine
+// node fans its latest outgoing batch out to the cluster, and returns what
+// should actually be sent to that peer.
+//
+// This harness can only rewrite messages a node already produced through
+// its normal Messages() channel: it has no hook into Node's internal
+// proposal or signing path, so it approximates attacks that would
+// otherwise require dual-signing inside the engine (equivocation, replay)
+// by reordering and duplicating what the node legitimately emitted across
+// rounds.
+type ByzantineBehavior interface {
+	// Corrupt receives the round-wide batch a byzantine node just produced,
+	// that round's number, and the index of the peer the batch is about to
+	// be sent to, and returns the batch that peer actually receives.
+	// nodeProgress's fan-out loop calls Corrupt once per destination peer
+	// for the same round, so a behavior that needs to remember what it
+	// sent "last round" must key that memory off round, not off each call.
+	Corrupt(round, to int, msgs []MsgTo) []MsgTo
+}
+
+// SilentBehavior withholds every message from peers in Silenced, while
+// forwarding to everyone else unchanged. With Silenced covering the whole
+// cluster it models a crashed/silent replica; with a subset it models
+// selective vote withholding.
+type SilentBehavior struct {
+	Silenced map[int]bool
+}
+
+func (b SilentBehavior) Corrupt(round, to int, msgs []MsgTo) []MsgTo {
+	if b.Silenced[to] {
+		return nil
+	}
+	return msgs
+}
+
+// EquivocateBehavior keeps the previous round's batch around and, for half
+// the peers (by parity of their index), delivers it alongside the current
+// round's instead of just the current round's. Peers on opposite sides of
+// the split observe different "latest" messages from the same byzantine
+// replica for the same round.
+//
+// This is NOT the same-view dual-signed equivocation the originating
+// request asked for (propose two different blocks at the same view, both
+// validly signed): this harness only ever sees messages a node already
+// produced and signed through its normal Messages() channel, so it cannot
+// make a node sign a second, different block at a view it already voted
+// on. What it models instead is cross-round replay/duplication, a weaker
+// adversary. Satisfying the original request requires a hook into
+// NewNode's proposal/signing path, which does not exist in this tree.
+type EquivocateBehavior struct {
+	mu       sync.Mutex
+	hasRound bool
+	round    int
+	prev     []MsgTo
+	cur      []MsgTo
+}
+
+func (b *EquivocateBehavior) Corrupt(round, to int, msgs []MsgTo) []MsgTo {
+	b.mu.Lock()
+	if !b.hasRound || round != b.round {
+		b.prev = b.cur
+		b.cur = msgs
+		b.round = round
+		b.hasRound = true
+	}
+	prev := b.prev
+	b.mu.Unlock()
+
+	if prev == nil || to%2 == 0 {
+		return msgs
+	}
+	return append(append([]MsgTo{}, prev...), msgs...)
+}
+
+// StaleVoteBehavior forwards the previous round's batch instead of the
+// current one (in the first round, with no previous batch, it forwards the
+// current one), modeling a replica stuck replaying stale messages rather
+// than voting honestly on the current view. It only replays messages the
+// node already produced, so it never sends future-view messages.
+type StaleVoteBehavior struct {
+	mu       sync.Mutex
+	hasRound bool
+	round    int
+	prev     []MsgTo
+	cur      []MsgTo
+}
+
+func (b *StaleVoteBehavior) Corrupt(round, to int, msgs []MsgTo) []MsgTo {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.hasRound || round != b.round {
+		b.prev = b.cur
+		b.cur = msgs
+		b.round = round
+		b.hasRound = true
+	}
+	if b.prev == nil {
+		return msgs
+	}
+	return b.prev
+}
+
+// TamperedQCBehavior corrupts the batch forwarded to every peer in
+// Targets by duplicating the batch's last message -- conventionally the
+// QC/cert-bearing one in this engine's broadcast order -- ahead of
+// everything else and dropping whatever would otherwise follow it. A
+// targeted peer observes what looks like a certificate repeated with
+// nothing behind it, rather than the honest batch the node actually
+// produced.
+//
+// This is NOT bit-level certificate forgery: MsgTo's Message field (and
+// any Cert carried inside it) is opaque to this tree -- its concrete type
+// belongs to the go-hotstuff engine that would live in node.go, which is
+// not part of this snapshot -- so this harness has no way to flip a
+// signature, voter set, or view number inside an already-formed QC. What
+// it models instead is relaying a QC-bearing message out of its intended
+// position in the batch, a coarser but related attack: the targeted peer
+// can no longer tell the relayed certificate belongs with the rest of
+// what the byzantine replica sent this round.
+type TamperedQCBehavior struct {
+	Targets map[int]bool
+}
+
+func (b TamperedQCBehavior) Corrupt(round, to int, msgs []MsgTo) []MsgTo {
+	if !b.Targets[to] || len(msgs) == 0 {
+		return msgs
+	}
+	last := msgs[len(msgs)-1]
+	return []MsgTo{last, last}
+}
+
+func behaviorsFor(n, f int, newBehavior func() ByzantineBehavior) map[int]ByzantineBehavior {
+	behaviors := make(map[int]ByzantineBehavior, f)
+	for i := 0; i < f; i++ {
+		behaviors[n-1-i] = newBehavior()
+	}
+	return behaviors
+}
+
+// byzantineBehaviors names every attack the originating request asked
+// for, each paired with the cluster it is exercised against below. Silent
+// voting and stale replay are both real: the byzantine node genuinely
+// withholds or replays what it produced. Equivocate and tampered-qc are
+// approximations -- see their doc comments -- because this harness can
+// only rewrite messages a node already produced and signed, with no hook
+// into the signing/QC-formation path node.go (absent from this snapshot)
+// would own.
+var byzantineBehaviors = []struct {
+	name        string
+	newBehavior func() ByzantineBehavior
+}{
+	{"silent", func() ByzantineBehavior {
+		return SilentBehavior{Silenced: map[int]bool{0: true, 1: true, 2: true, 3: true, 4: true, 5: true, 6: true, 7: true, 8: true, 9: true}}
+	}},
+	{"equivocate-approx", func() ByzantineBehavior { return &EquivocateBehavior{} }},
+	{"stale", func() ByzantineBehavior { return &StaleVoteBehavior{} }},
+	{"tampered-qc-approx", func() ByzantineBehavior {
+		return TamperedQCBehavior{Targets: map[int]bool{0: true, 1: true, 2: true, 3: true, 4: true, 5: true, 6: true, 7: true, 8: true, 9: true}}
+	}},
+}
+
+// byzantineClusters gives, for each cluster size the originating request
+// named, the largest f HotStuff tolerates (n >= 3f+1) so every row below
+// tests liveness right at the boundary the protocol claims to guarantee.
+var byzantineClusters = []struct{ n, f int }{
+	{4, 1},
+	{7, 2},
+	{10, 3},
+}
+
+func TestByzantineClusters(t *testing.T) {
+	tt := []struct {
+		name        string
+		n           int
+		f           int
+		newBehavior func() ByzantineBehavior
+	}{}
+	for _, behavior := range byzantineBehaviors {
+		for _, cluster := range byzantineClusters {
+			tt = append(tt, struct {
+				name        string
+				n           int
+				f           int
+				newBehavior func() ByzantineBehavior
+			}{
+				name:        fmt.Sprintf("n%df%d-%s", cluster.n, cluster.f, behavior.name),
+				n:           cluster.n,
+				f:           cluster.f,
+				newBehavior: behavior.newBehavior,
+			})
+		}
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			nodes := createNodes(t, tc.n, 20*time.Millisecond)
+			behaviors := behaviorsFor(tc.n, tc.f, tc.newBehavior)
+			sched := netsim.NewScheduler(*seed, netsim.Schedule{
+				{Kind: netsim.Synchronous},
+			})
+
+			errs := runNodes(t, nodes, sched, behaviors, 3)
+
+			// Safety must hold regardless of how the byzantine replicas
+			// behave: runNodes already asserted chain consistency across
+			// all n nodes above. Liveness -- every honest node committing
+			// within the deadline -- only holds while f is within the
+			// protocol's tolerated fraction (n >= 3f+1).
+			if tc.n >= 3*tc.f+1 {
+				for i, err := range errs {
+					if behaviors[i] != nil {
+						continue
+					}
+					require.NoError(t, err, fmt.Sprintf("honest node %d should commit", i))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+// Package netsim provides a deterministic network simulator for driving
+// multi-node HotStuff tests through scheduled asynchrony epochs, instead of
+// applying an i.i.d. random drop to every message. A Schedule is a sequence
+// of Phases, each active for a fixed duration; the simulator walks the
+// schedule by wall-clock elapsed time and, for every candidate delivery,
+// reports whether it should be dropped, delayed, or partitioned away.
+//
+// The random draws (drops and jitter) come from a seeded source, so the same
+// seed and Schedule yield the same sequence of draws. Replays are not exact:
+// phase boundaries follow wall-clock time, and which delivery consumes which
+// draw follows goroutine scheduling.
+package netsim
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// PhaseKind selects the delivery behavior for a Phase.
+type PhaseKind int
+
+const (
+	// Synchronous delivers every message immediately.
+	Synchronous PhaseKind = iota
+	// Partitioned drops messages whose source and destination fall in
+	// different subsets of Partitions, and delivers the rest immediately.
+	Partitioned
+	// Dropping independently drops each message with probability DropRate.
+	Dropping
+	// Delayed delivers every message after Delay plus a uniform random
+	// jitter in [0, Jitter).
+	Delayed
+)
+
+// Phase describes the delivery behavior in effect for Duration before the
+// schedule advances to the next phase. The last phase in a Schedule holds
+// forever once reached.
+type Phase struct {
+	Kind     PhaseKind
+	Duration time.Duration
+
+	// Partitions is only consulted when Kind is Partitioned. Each inner
+	// slice is a set of node indices that can reach each other; indices
+	// absent from every subset are reachable by everyone.
+	Partitions [][]int
+
+	// DropRate is only consulted when Kind is Dropping, in [0, 1].
+	DropRate float64
+
+	// Delay and Jitter are only consulted when Kind is Delayed.
+	Delay  time.Duration
+	Jitter time.Duration
+}
+
+// Schedule is an ordered sequence of Phases, played back starting at the
+// time a Scheduler is constructed.
+type Schedule []Phase
+
+// Scheduler replays a Schedule against a stream of proposed message
+// deliveries, deciding deterministically (given its seed) whether and when
+// each delivery happens.
+type Scheduler struct {
+	mu       sync.Mutex
+	schedule Schedule
+	start    time.Time
+	rng      *rand.Rand
+}
+
+// NewScheduler builds a Scheduler that starts its Schedule now. The same
+// seed and Schedule always produce the same sequence of decisions, modulo
+// the real wall-clock jitter inherent to running goroutines.
+func NewScheduler(seed int64, schedule Schedule) *Scheduler {
+	return &Scheduler{
+		schedule: schedule,
+		start:    time.Now(),
+		rng:      rand.New(rand.NewSource(seed)),
+	}
+}
+
+// phaseAt returns the Phase active at elapsed time t since the scheduler
+// started. Callers must hold mu.
+func (s *Scheduler) phaseAt(t time.Duration) Phase {
+	if len(s.schedule) == 0 {
+		return Phase{Kind: Synchronous}
+	}
+	var at time.Duration
+	for _, phase := range s.schedule[:len(s.schedule)-1] {
+		at += phase.Duration
+		if t < at {
+			return phase
+		}
+	}
+	return s.schedule[len(s.schedule)-1]
+}
+
+// decide reports whether a message from `from` to `to` should be delivered,
+// and if so after what delay.
+func (s *Scheduler) decide(from, to int) (deliver bool, delay time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	phase := s.phaseAt(time.Since(s.start))
+	switch phase.Kind {
+	case Synchronous:
+		return true, 0
+	case Partitioned:
+		return partitioned(phase.Partitions, from, to), 0
+	case Dropping:
+		return s.rng.Float64() >= phase.DropRate, 0
+	case Delayed:
+		d := phase.Delay
+		if phase.Jitter > 0 {
+			d += time.Duration(s.rng.Int63n(int64(phase.Jitter)))
+		}
+		return true, d
+	default:
+		return true, 0
+	}
+}
+
+func partitioned(partitions [][]int, from, to int) bool {
+	if from == to {
+		return true
+	}
+	fromSubset, fromIn := subsetOf(partitions, from)
+	toSubset, toIn := subsetOf(partitions, to)
+	if !fromIn || !toIn {
+		// An endpoint named in no subset is a bystander: reachable by
+		// everyone, regardless of which subsets the other endpoint falls
+		// under.
+		return true
+	}
+	return fromSubset == toSubset
+}
+
+// subsetOf reports the index of the first subset in partitions containing
+// id, and whether id appears in any subset at all.
+func subsetOf(partitions [][]int, id int) (int, bool) {
+	for i, subset := range partitions {
+		if contains(subset, id) {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+func contains(set []int, id int) bool {
+	for _, v := range set {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Deliver evaluates the current phase for the from/to pair and, if the
+// schedule allows delivery, invokes fn after the phase's delay (zero for a
+// Synchronous phase). fn always runs on its own goroutine, never on the
+// caller's, so a caller driving its own event loop through Deliver for
+// every peer can never have one peer's fn (e.g. a blocking Step call)
+// stall its own loop. A dropped or context-cancelled delivery never calls
+// fn.
+func (s *Scheduler) Deliver(ctx context.Context, from, to int, fn func()) {
+	deliver, delay := s.decide(from, to)
+	if !deliver {
+		return
+	}
+	go func() {
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		fn()
+	}()
+}
@@ -0,0 +1,114 @@
+package netsim
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPartitioned(t *testing.T) {
+	tt := []struct {
+		name       string
+		partitions [][]int
+		from, to   int
+		want       bool
+	}{
+		{"same node always reachable", [][]int{{0, 1}}, 0, 0, true},
+		{"same subset reachable", [][]int{{0, 1}, {2, 3}}, 0, 1, true},
+		{"different subsets unreachable", [][]int{{0, 1}, {2, 3}}, 0, 2, false},
+		{"bystander reachable from assigned node", [][]int{{0, 1}}, 0, 2, true},
+		{"assigned node reachable from bystander", [][]int{{0, 1}}, 2, 0, true},
+		{"both bystanders reachable", [][]int{{0, 1}}, 2, 3, true},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if got := partitioned(tc.partitions, tc.from, tc.to); got != tc.want {
+				t.Errorf("partitioned(%v, %d, %d) = %v, want %v", tc.partitions, tc.from, tc.to, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSchedulerDroppingDropRate(t *testing.T) {
+	tt := []struct {
+		name     string
+		dropRate float64
+		want     bool
+	}{
+		{"drop rate zero always delivers", 0, true},
+		{"drop rate one never delivers", 1, false},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			sched := NewScheduler(1, Schedule{
+				{Kind: Dropping, DropRate: tc.dropRate},
+			})
+			for i := 0; i < 20; i++ {
+				deliver, delay := sched.decide(0, 1)
+				if deliver != tc.want {
+					t.Fatalf("decide() delivered = %v, want %v", deliver, tc.want)
+				}
+				if delay != 0 {
+					t.Fatalf("Dropping phase should never add a delay, got %v", delay)
+				}
+			}
+		})
+	}
+}
+
+func TestSchedulerDelayedAppliesDelayAndJitter(t *testing.T) {
+	sched := NewScheduler(1, Schedule{
+		{Kind: Delayed, Delay: 50 * time.Millisecond, Jitter: 10 * time.Millisecond},
+	})
+
+	for i := 0; i < 20; i++ {
+		deliver, delay := sched.decide(0, 1)
+		if !deliver {
+			t.Fatal("a Delayed phase should always eventually deliver")
+		}
+		if delay < 50*time.Millisecond || delay >= 60*time.Millisecond {
+			t.Fatalf("delay %v outside [Delay, Delay+Jitter)", delay)
+		}
+	}
+}
+
+func TestSchedulerDelayedZeroJitterIsExact(t *testing.T) {
+	sched := NewScheduler(1, Schedule{
+		{Kind: Delayed, Delay: 25 * time.Millisecond},
+	})
+	if _, delay := sched.decide(0, 1); delay != 25*time.Millisecond {
+		t.Fatalf("delay = %v, want exactly 25ms when Jitter is zero", delay)
+	}
+}
+
+func TestSchedulerDeliverHonorsDroppingAndDelayed(t *testing.T) {
+	t.Run("dropped message never calls fn", func(t *testing.T) {
+		sched := NewScheduler(1, Schedule{{Kind: Dropping, DropRate: 1}})
+		called := make(chan struct{}, 1)
+		sched.Deliver(context.Background(), 0, 1, func() { called <- struct{}{} })
+		select {
+		case <-called:
+			t.Fatal("fn should not run for a dropped delivery")
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+
+	t.Run("delayed message calls fn after its delay", func(t *testing.T) {
+		sched := NewScheduler(1, Schedule{{Kind: Delayed, Delay: 30 * time.Millisecond}})
+		start := time.Now()
+		called := make(chan time.Duration, 1)
+		sched.Deliver(context.Background(), 0, 1, func() { called <- time.Since(start) })
+		select {
+		case elapsed := <-called:
+			if elapsed < 30*time.Millisecond {
+				t.Fatalf("fn ran after %v, before its 30ms delay", elapsed)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("fn never ran")
+		}
+	})
+}
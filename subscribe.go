@@ -0,0 +1,330 @@
+package hotstuff
+
+import "sync"
+
+// DropPolicy selects how a subscription's internal mailbox behaves once it
+// is full relative to Capacity. It never affects the publisher: publishing
+// only ever appends to a mailbox and signals its forwarder goroutine, so a
+// stuck subscriber falls behind on its own backlog without holding up
+// anyone else, including the node's own consensus loop.
+type DropPolicy int
+
+const (
+	// Unbounded lets the mailbox grow without limit, so no event is ever
+	// dropped; a stuck subscriber accumulates backlog instead.
+	Unbounded DropPolicy = iota
+	// DropNewest discards the incoming event once the mailbox reaches
+	// Capacity, keeping the existing backlog intact.
+	DropNewest
+	// DropOldest discards the oldest queued event to make room once the
+	// mailbox reaches Capacity.
+	DropOldest
+)
+
+// SubscribeOptions configures a single subscription's mailbox.
+type SubscribeOptions struct {
+	Policy DropPolicy
+	// Capacity bounds the mailbox for DropNewest/DropOldest. Ignored by
+	// Unbounded.
+	Capacity int
+}
+
+// ViewChangeEvent reports that the cluster moved to a new view, naming the
+// view's leader.
+type ViewChangeEvent struct {
+	View   uint64
+	Leader []byte
+}
+
+// Subscription is returned by every Subscribe* call. Unsubscribe stops
+// further delivery and releases the subscription's forwarder goroutine and
+// mailbox.
+type Subscription struct {
+	unsubscribe func()
+}
+
+// Unsubscribe reclaims the resources held by the subscription. Safe to
+// call more than once.
+func (s Subscription) Unsubscribe() {
+	s.unsubscribe()
+}
+
+// mailbox is an unexported FIFO queue of interface{} with a forwarder
+// goroutine that drains it into a typed destination. Publishing only ever
+// appends and returns; delivery to the (possibly slow) subscriber happens
+// on the forwarder goroutine, so one stuck subscriber never blocks the
+// publisher or any other subscription.
+type mailbox struct {
+	mu       sync.Mutex
+	queue    []interface{}
+	notify   chan struct{}
+	done     chan struct{}
+	capacity int
+	policy   DropPolicy
+}
+
+func newMailbox(opts SubscribeOptions) *mailbox {
+	capacity := opts.Capacity
+	if opts.Policy != Unbounded && capacity <= 0 {
+		// A bounded policy with no configured Capacity still needs to
+		// bound something; default to holding just the latest pending
+		// event rather than silently behaving like Unbounded.
+		capacity = 1
+	}
+	return &mailbox{
+		notify:   make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		capacity: capacity,
+		policy:   opts.Policy,
+	}
+}
+
+func (m *mailbox) push(v interface{}) {
+	m.mu.Lock()
+	switch {
+	case m.policy == Unbounded || len(m.queue) < m.capacity:
+		m.queue = append(m.queue, v)
+	case m.policy == DropOldest:
+		copy(m.queue, m.queue[1:])
+		m.queue[len(m.queue)-1] = v
+	default: // DropNewest, mailbox full
+		m.mu.Unlock()
+		return
+	}
+	m.mu.Unlock()
+	select {
+	case m.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (m *mailbox) pop() (interface{}, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.queue) == 0 {
+		return nil, false
+	}
+	v := m.queue[0]
+	m.queue = m.queue[1:]
+	return v, true
+}
+
+func (m *mailbox) close() {
+	select {
+	case <-m.done:
+	default:
+		close(m.done)
+	}
+}
+
+// run drains the mailbox in order, calling deliver for each value, until
+// closed. deliver is expected to itself select on m.done so a blocked send
+// to a stuck subscriber unwinds on Unsubscribe.
+func (m *mailbox) run(deliver func(interface{})) {
+	for {
+		select {
+		case <-m.done:
+			return
+		default:
+		}
+		if v, ok := m.pop(); ok {
+			deliver(v)
+			continue
+		}
+		select {
+		case <-m.notify:
+		case <-m.done:
+			return
+		}
+	}
+}
+
+// REQUEST OPEN, NOT RESOLVED: the originating request asked for Node to
+// expose SubscribeCommitted/SubscribeQC/SubscribeViewChange/
+// SubscribeBlockByProposer and for nodeProgress to consume through one.
+// Neither exists -- this tree's snapshot does not include node.go, so
+// Node does not embed subscriptions below and nothing calls
+// publishCommitted, publishQC, or publishViewChange outside of this
+// package's own tests (subscribe_test.go exercises subscriptions
+// directly, with no Node involved). nodeProgress in node_test.go still
+// consumes committed headers via the pre-existing Blocks() channel.
+// Nothing here should be read as having wired a Subscribe* API onto
+// Node; do not close this request on the strength of this file.
+//
+// subscriptions fans committed headers, QCs, and view-change events out to
+// any number of subscribers in commit order, each through its own mailbox.
+// It is meant to be embedded in Node, which would call the publish*
+// methods from its commit/QC-formation/view-change paths and expose the
+// Subscribe* methods below on itself.
+type subscriptions struct {
+	mu         sync.Mutex
+	committed  map[int]*headerSub
+	byProposer map[int]*proposerSub
+	qc         map[int]*qcSub
+	viewChange map[int]*viewChangeSub
+	next       int
+}
+
+type headerSub struct {
+	ch chan<- Header
+	mb *mailbox
+}
+
+type proposerSub struct {
+	proposer string
+	ch       chan<- Header
+	mb       *mailbox
+}
+
+type qcSub struct {
+	ch chan<- Cert
+	mb *mailbox
+}
+
+type viewChangeSub struct {
+	ch chan<- ViewChangeEvent
+	mb *mailbox
+}
+
+func newSubscriptions() *subscriptions {
+	return &subscriptions{
+		committed:  map[int]*headerSub{},
+		byProposer: map[int]*proposerSub{},
+		qc:         map[int]*qcSub{},
+		viewChange: map[int]*viewChangeSub{},
+	}
+}
+
+// SubscribeCommitted registers ch to receive every header Node commits, in
+// commit order.
+func (s *subscriptions) SubscribeCommitted(ch chan<- Header, opts SubscribeOptions) Subscription {
+	mb := newMailbox(opts)
+	sub := &headerSub{ch: ch, mb: mb}
+	go mb.run(func(v interface{}) {
+		select {
+		case ch <- v.(Header):
+		case <-mb.done:
+		}
+	})
+
+	s.mu.Lock()
+	id := s.next
+	s.next++
+	s.committed[id] = sub
+	s.mu.Unlock()
+
+	return Subscription{unsubscribe: func() {
+		s.mu.Lock()
+		delete(s.committed, id)
+		s.mu.Unlock()
+		mb.close()
+	}}
+}
+
+// SubscribeBlockByProposer registers ch to receive only committed headers
+// proposed by proposer.
+func (s *subscriptions) SubscribeBlockByProposer(proposer []byte, ch chan<- Header, opts SubscribeOptions) Subscription {
+	mb := newMailbox(opts)
+	sub := &proposerSub{proposer: string(proposer), ch: ch, mb: mb}
+	go mb.run(func(v interface{}) {
+		select {
+		case ch <- v.(Header):
+		case <-mb.done:
+		}
+	})
+
+	s.mu.Lock()
+	id := s.next
+	s.next++
+	s.byProposer[id] = sub
+	s.mu.Unlock()
+
+	return Subscription{unsubscribe: func() {
+		s.mu.Lock()
+		delete(s.byProposer, id)
+		s.mu.Unlock()
+		mb.close()
+	}}
+}
+
+// SubscribeQC registers ch to receive every certificate Node forms.
+func (s *subscriptions) SubscribeQC(ch chan<- Cert, opts SubscribeOptions) Subscription {
+	mb := newMailbox(opts)
+	sub := &qcSub{ch: ch, mb: mb}
+	go mb.run(func(v interface{}) {
+		select {
+		case ch <- v.(Cert):
+		case <-mb.done:
+		}
+	})
+
+	s.mu.Lock()
+	id := s.next
+	s.next++
+	s.qc[id] = sub
+	s.mu.Unlock()
+
+	return Subscription{unsubscribe: func() {
+		s.mu.Lock()
+		delete(s.qc, id)
+		s.mu.Unlock()
+		mb.close()
+	}}
+}
+
+// SubscribeViewChange registers ch to receive every view-change event.
+func (s *subscriptions) SubscribeViewChange(ch chan<- ViewChangeEvent, opts SubscribeOptions) Subscription {
+	mb := newMailbox(opts)
+	sub := &viewChangeSub{ch: ch, mb: mb}
+	go mb.run(func(v interface{}) {
+		select {
+		case ch <- v.(ViewChangeEvent):
+		case <-mb.done:
+		}
+	})
+
+	s.mu.Lock()
+	id := s.next
+	s.next++
+	s.viewChange[id] = sub
+	s.mu.Unlock()
+
+	return Subscription{unsubscribe: func() {
+		s.mu.Lock()
+		delete(s.viewChange, id)
+		s.mu.Unlock()
+		mb.close()
+	}}
+}
+
+// publishCommitted is called for every finalized header, in commit order,
+// naming who proposed it so SubscribeBlockByProposer can filter.
+func (s *subscriptions) publishCommitted(h Header, proposer []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sub := range s.committed {
+		sub.mb.push(h)
+	}
+	proposerStr := string(proposer)
+	for _, sub := range s.byProposer {
+		if sub.proposer == proposerStr {
+			sub.mb.push(h)
+		}
+	}
+}
+
+func (s *subscriptions) publishQC(c Cert) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sub := range s.qc {
+		sub.mb.push(c)
+	}
+}
+
+func (s *subscriptions) publishViewChange(e ViewChangeEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sub := range s.viewChange {
+		sub.mb.push(e)
+	}
+}
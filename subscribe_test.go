@@ -0,0 +1,146 @@
+package hotstuff
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscriptionsMultipleSubscribersGetFullOrderedStream(t *testing.T) {
+	s := newSubscriptions()
+
+	const n = 3
+	chans := make([]chan Header, n)
+	for i := range chans {
+		chans[i] = make(chan Header, 1)
+		s.SubscribeCommitted(chans[i], SubscribeOptions{Policy: Unbounded})
+	}
+
+	for view := uint64(1); view <= 10; view++ {
+		s.publishCommitted(Header{View: view}, nil)
+	}
+
+	for _, ch := range chans {
+		for view := uint64(1); view <= 10; view++ {
+			select {
+			case h := <-ch:
+				require.Equal(t, view, h.View)
+			case <-time.After(time.Second):
+				t.Fatalf("timed out waiting for view %d", view)
+			}
+		}
+	}
+}
+
+func TestSubscriptionsUnsubscribeReclaimsResources(t *testing.T) {
+	s := newSubscriptions()
+
+	ch := make(chan Header, 1)
+	sub := s.SubscribeCommitted(ch, SubscribeOptions{Policy: Unbounded})
+	require.Len(t, s.committed, 1)
+
+	sub.Unsubscribe()
+	require.Len(t, s.committed, 0)
+
+	// Publishing after Unsubscribe must not deliver or block.
+	s.publishCommitted(Header{View: 1}, nil)
+	select {
+	case <-ch:
+		t.Fatal("unsubscribed channel should not receive further events")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Unsubscribing twice must not panic.
+	sub.Unsubscribe()
+}
+
+func TestSubscriptionsStuckSubscriberDoesNotBlockOthers(t *testing.T) {
+	s := newSubscriptions()
+
+	stuck := make(chan Header) // never read from
+	s.SubscribeCommitted(stuck, SubscribeOptions{Policy: Unbounded})
+
+	fast := make(chan Header, 100)
+	s.SubscribeCommitted(fast, SubscribeOptions{Policy: Unbounded})
+
+	done := make(chan struct{})
+	go func() {
+		for view := uint64(1); view <= 50; view++ {
+			s.publishCommitted(Header{View: view}, nil)
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("publish blocked on a stuck subscriber")
+	}
+
+	for view := uint64(1); view <= 50; view++ {
+		select {
+		case h := <-fast:
+			require.Equal(t, view, h.View)
+		case <-time.After(time.Second):
+			t.Fatalf("fast subscriber missed view %d", view)
+		}
+	}
+}
+
+func TestSubscriptionsDropNewestBoundsMailbox(t *testing.T) {
+	s := newSubscriptions()
+
+	ch := make(chan Header) // never read from, so the mailbox always fills
+	s.SubscribeCommitted(ch, SubscribeOptions{Policy: DropNewest, Capacity: 2})
+
+	for view := uint64(1); view <= 10; view++ {
+		s.publishCommitted(Header{View: view}, nil)
+	}
+
+	sub := s.committed[0]
+	sub.mb.mu.Lock()
+	qlen := len(sub.mb.queue)
+	sub.mb.mu.Unlock()
+	require.LessOrEqual(t, qlen, 2)
+}
+
+func TestSubscribeBlockByProposerFilters(t *testing.T) {
+	s := newSubscriptions()
+
+	alice := []byte("alice")
+	bob := []byte("bob")
+
+	ch := make(chan Header, 10)
+	sub := s.SubscribeBlockByProposer(alice, ch, SubscribeOptions{Policy: Unbounded})
+	defer sub.Unsubscribe()
+
+	s.publishCommitted(Header{View: 1}, alice)
+	s.publishCommitted(Header{View: 2}, bob)
+	s.publishCommitted(Header{View: 3}, alice)
+
+	require.Equal(t, uint64(1), (<-ch).View)
+	require.Equal(t, uint64(3), (<-ch).View)
+
+	select {
+	case h := <-ch:
+		t.Fatalf("unexpected header from non-matching proposer: %+v", h)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// TestNodeSubscribersGetFullOrderedStream_RequestOpen is a placeholder,
+// not coverage, and its skip is not a pass: this request -- Node exposing
+// a working Subscribe* API -- is not resolved by this tree. Proving
+// "multiple concurrent subscribers each get the full ordered stream" and
+// "a stuck subscriber does not block consensus" through Node requires
+// Node to embed subscriptions and call publishCommitted/publishQC/
+// publishViewChange from its commit/QC-formation/view-change paths, which
+// lives outside this snapshot's tree (node.go is not present here). The
+// TestSubscriptions* tests above, which exercise subscriptions directly
+// with no Node involved, are the closest coverage available until a
+// future change lands node.go and wires this for real; until then, this
+// request should stay open, not be treated as closed.
+func TestNodeSubscribersGetFullOrderedStream_RequestOpen(t *testing.T) {
+	t.Skip("REQUEST OPEN: requires Node to embed subscriptions and call its publish* methods; node.go is not part of this snapshot")
+}